@@ -0,0 +1,392 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Command edwards-gen-vectors generates JSON test vectors for package
+// edwards's testdata-driven harness by driving the real signing pipeline:
+// the plain single-nonce threshold scheme, the two-nonce MuSig2 protocol
+// and Ed25519 adaptor signatures. Run without arguments it prints a set of
+// vectors covering 2-of-2 through 10-of-10 (plus a handful of adaptor
+// vectors) to stdout; redirect to testdata/threshold_vectors.json to
+// refresh the frozen set that TestVectorsFromFile in package edwards loads
+// and replays. Every m-of-m size is generated twice, once honestly and
+// once with a signer's key corrupted after aggregation, so the frozen set
+// exercises the expected-failure path as well as the happy path.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/OliviaSong/hcd/hcec/edwards"
+)
+
+// Protocol names distinguish the three signing schemes a single vector
+// list can carry, so that one loader and one JSON schema cover all of
+// them rather than one file per protocol.
+const (
+	protocolThreshold = "threshold"
+	protocolMuSig2    = "musig2-two-nonce"
+	protocolAdaptor   = "adaptor"
+)
+
+type signerVector struct {
+	Privkey          string `json:"privkey"`
+	Pubkey           string `json:"pubkey,omitempty"`
+	PrivateNonce     string `json:"privateNonce,omitempty"`
+	PubKeySumLocal   string `json:"pubKeySumLocal,omitempty"`
+	PartialSignature string `json:"partialSignature,omitempty"`
+	KeyAggCoeff      string `json:"keyAggCoeff,omitempty"`
+}
+
+// vector is the single schema shared by all three protocols. Fields that
+// do not apply to a given Protocol are left empty: threshold vectors use
+// Signers/CombinedSignature; musig2-two-nonce vectors additionally use
+// Signers[].KeyAggCoeff and the aggregate nonce pair NonceR1/NonceR2;
+// adaptor vectors use T/TSecret/AdaptorR/AdaptorS plus CombinedSignature
+// for the completed signature, and Signers[0].PrivateNonce for the nonce
+// scalar AdaptorSign was given, so a loader can replay the exact signature
+// rather than only re-deriving one from fresh randomness.
+type vector struct {
+	Description       string         `json:"description"`
+	Protocol          string         `json:"protocol"`
+	Msg               string         `json:"msg"`
+	Signers           []signerVector `json:"signers,omitempty"`
+	NonceR1           string         `json:"nonceR1,omitempty"`
+	NonceR2           string         `json:"nonceR2,omitempty"`
+	CombinedSignature string         `json:"combinedSignature,omitempty"`
+	T                 string         `json:"T,omitempty"`
+	TSecret           string         `json:"t,omitempty"`
+	AdaptorR          string         `json:"adaptorR,omitempty"`
+	AdaptorS          string         `json:"adaptorS,omitempty"`
+	Corrupt           bool           `json:"corrupt"`
+}
+
+func main() {
+	maxSignatories := flag.Int("max", 10, "largest m-of-m threshold size to generate")
+	adaptorVariants := flag.Int("adaptor-variants", 4, "number of adaptor-signature vectors to generate")
+	flag.Parse()
+
+	curve := new(edwards.TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	var vectors []vector
+	for n := 2; n <= *maxSignatories; n++ {
+		for _, corrupt := range []bool{false, true} {
+			v, err := generateThresholdVector(curve, n, corrupt)
+			fatalIf(err)
+			v.Description = fmt.Sprintf("%d-of-%d threshold, corrupt=%v", n, n, corrupt)
+			vectors = append(vectors, *v)
+
+			v, err = generateMuSig2Vector(curve, n, corrupt)
+			fatalIf(err)
+			v.Description = fmt.Sprintf("%d-of-%d musig2 two-nonce, corrupt=%v", n, n, corrupt)
+			vectors = append(vectors, *v)
+		}
+	}
+	for i := 0; i < *adaptorVariants; i++ {
+		corrupt := i%2 == 1
+		v, err := generateAdaptorVector(curve, corrupt)
+		fatalIf(err)
+		v.Description = fmt.Sprintf("adaptor signature, variant %d, corrupt=%v", i, corrupt)
+		vectors = append(vectors, *v)
+	}
+
+	out := struct {
+		Vectors []vector `json:"vectors"`
+	}{Vectors: vectors}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintln(os.Stderr, "edwards-gen-vectors:", err)
+		os.Exit(1)
+	}
+}
+
+func fatalIf(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "edwards-gen-vectors:", err)
+		os.Exit(1)
+	}
+}
+
+// randScalar returns a random 32-byte scalar reduced mod curve.N.
+func randScalar(curve *edwards.TwistedEdwardsCurve) ([]byte, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	n := new(big.Int).SetBytes(buf)
+	n.Mod(n, curve.N)
+	scalar := make([]byte, 32)
+	nBytes := n.Bytes()
+	copy(scalar[32-len(nBytes):], nBytes)
+	return scalar, nil
+}
+
+// generateThresholdVector builds one n-of-n plain threshold test vector by
+// running the real signing pipeline, mirroring runLiveThresholdVector's
+// construction (random keys and nonces). When corrupt is true, the first
+// signer's private key is flipped after key aggregation, so PubKeySumLocal
+// and the recorded partial/combined signatures are all internally
+// consistent with each other but the combined signature does not verify.
+func generateThresholdVector(curve *edwards.TwistedEdwardsCurve, n int, corrupt bool) (*vector, error) {
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		return nil, err
+	}
+
+	privs := make([]*edwards.PrivateKey, n)
+	pubs := make([]*edwards.PublicKey, n)
+	for i := 0; i < n; i++ {
+		scalar, err := randScalar(curve)
+		if err != nil {
+			return nil, err
+		}
+		priv, pub, err := edwards.PrivKeyFromScalar(curve, scalar)
+		if err != nil {
+			return nil, err
+		}
+		privs[i] = priv
+		pubs[i] = pub
+	}
+	aggPub := edwards.CombinePubkeys(curve, pubs)
+
+	privScalars := make([][]byte, n)
+	for i := range privs {
+		privScalars[i] = privs[i].Serialize()
+	}
+	if corrupt {
+		corrupted := make([]byte, len(privScalars[0]))
+		copy(corrupted, privScalars[0])
+		corrupted[0] ^= 1
+		privScalars[0] = corrupted
+	}
+
+	privNonces := make([]*edwards.PrivateKey, n)
+	pubNonces := make([]*edwards.PublicKey, n)
+	for i := 0; i < n; i++ {
+		scalar, err := randScalar(curve)
+		if err != nil {
+			return nil, err
+		}
+		priv, pub, err := edwards.PrivKeyFromScalar(curve, scalar)
+		if err != nil {
+			return nil, err
+		}
+		privNonces[i] = priv
+		pubNonces[i] = pub
+	}
+	pubNonceSum := edwards.CombinePubkeys(curve, pubNonces)
+
+	sigs := make([]*edwards.Signature, n)
+	signers := make([]signerVector, n)
+	for i := 0; i < n; i++ {
+		r, s, err := edwards.SchnorrPartialSignRaw(curve, msg, privScalars[i],
+			aggPub.Serialize(), privNonces[i].Serialize(), pubNonceSum.Serialize())
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = edwards.NewSignature(r, s)
+
+		signers[i] = signerVector{
+			Privkey:          hex.EncodeToString(privScalars[i]),
+			PrivateNonce:     hex.EncodeToString(privNonces[i].Serialize()),
+			PubKeySumLocal:   hex.EncodeToString(aggPub.Serialize()),
+			PartialSignature: hex.EncodeToString(sigs[i].Serialize()),
+		}
+	}
+
+	combined, err := edwards.SchnorrCombineSigs(curve, sigs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vector{
+		Protocol:          protocolThreshold,
+		Msg:               hex.EncodeToString(msg),
+		Signers:           signers,
+		CombinedSignature: hex.EncodeToString(combined.Serialize()),
+		Corrupt:           corrupt,
+	}, nil
+}
+
+// generateMuSig2Vector builds one n-of-n two-nonce MuSig2 test vector,
+// mirroring TestMuSig2TwoNonce's construction: key aggregation with
+// per-signer coefficients, deterministic nonce generation and aggregation,
+// partial signing and combination. As with generateThresholdVector, a
+// corrupt vector flips the first signer's key after aggregation so every
+// recorded field stays internally consistent but verification fails; each
+// signer's Pubkey is recorded separately from its (possibly corrupted)
+// Privkey so a loader can redo key aggregation against the same honest
+// pubkeys this vector was actually aggregated and signed under.
+func generateMuSig2Vector(curve *edwards.TwistedEdwardsCurve, n int, corrupt bool) (*vector, error) {
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		return nil, err
+	}
+
+	privs := make([]*edwards.PrivateKey, n)
+	pubs := make([]*edwards.PublicKey, n)
+	for i := 0; i < n; i++ {
+		scalar, err := randScalar(curve)
+		if err != nil {
+			return nil, err
+		}
+		priv, pub, err := edwards.PrivKeyFromScalar(curve, scalar)
+		if err != nil {
+			return nil, err
+		}
+		privs[i] = priv
+		pubs[i] = pub
+	}
+
+	aggPub, coeffs, err := edwards.AggregatePubKeys(curve, pubs)
+	if err != nil {
+		return nil, err
+	}
+	aggPubBytes := aggPub.Serialize()
+
+	privScalars := make([][]byte, n)
+	for i := range privs {
+		privScalars[i] = privs[i].Serialize()
+	}
+	if corrupt {
+		corrupted := make([]byte, len(privScalars[0]))
+		copy(corrupted, privScalars[0])
+		corrupted[0] ^= 1
+		privScalars[0] = corrupted
+	}
+
+	secNonces := make([]*edwards.SecNonces, n)
+	pubNonces := make([]*edwards.PubNonces, n)
+	for i := 0; i < n; i++ {
+		sessionID := []byte{byte(i)}
+		sec, pub, err := edwards.GenNonces(curve, privScalars[i], aggPubBytes[:], msg, nil, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		secNonces[i] = sec
+		pubNonces[i] = pub
+	}
+
+	aggNonces, err := edwards.AggregateNonces(curve, pubNonces)
+	if err != nil {
+		return nil, err
+	}
+
+	sigs := make([]*edwards.Signature, n)
+	signers := make([]signerVector, n)
+	for i := 0; i < n; i++ {
+		r, s, err := edwards.MuSig2Sign(curve, secNonces[i], aggNonces, aggPub, coeffs[i],
+			privScalars[i], msg)
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = edwards.NewSignature(r, s)
+
+		signers[i] = signerVector{
+			Privkey:     hex.EncodeToString(privScalars[i]),
+			Pubkey:      hex.EncodeToString(pubs[i].Serialize()),
+			KeyAggCoeff: hex.EncodeToString(coeffs[i].Bytes()),
+		}
+	}
+
+	combined, err := edwards.MuSig2CombineSigs(curve, sigs)
+	if err != nil {
+		return nil, err
+	}
+
+	r1Bytes := aggNonces.GetR1().Serialize()
+	r2Bytes := aggNonces.GetR2().Serialize()
+
+	return &vector{
+		Protocol:          protocolMuSig2,
+		Msg:               hex.EncodeToString(msg),
+		Signers:           signers,
+		NonceR1:           hex.EncodeToString(r1Bytes[:]),
+		NonceR2:           hex.EncodeToString(r2Bytes[:]),
+		CombinedSignature: hex.EncodeToString(combined.Serialize()),
+		Corrupt:           corrupt,
+	}, nil
+}
+
+// generateAdaptorVector builds one Ed25519 adaptor-signature test vector,
+// mirroring runLiveAdaptorVector's construction: sign, verify, adapt and
+// extract. The nonce scalar AdaptorSign is given is itself recorded
+// (Signers[0].PrivateNonce), along with the resulting adaptor signature
+// (AdaptorR/AdaptorS) and completed signature (CombinedSignature), so a
+// loader can replay the fixed inputs and check the fixed outputs rather
+// than only re-deriving fresh ones. A corrupt vector records the wrong
+// tweak secret t, which does not match the tweak point T used to sign, so
+// adapting with it produces a completed signature that fails to verify.
+func generateAdaptorVector(curve *edwards.TwistedEdwardsCurve, corrupt bool) (*vector, error) {
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		return nil, err
+	}
+
+	privScalar, err := randScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	priv, pub, err := edwards.PrivKeyFromScalar(curve, privScalar)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceScalar, err := randScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	tSecret, err := randScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	_, tPoint, err := edwards.PrivKeyFromScalar(curve, tSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	adaptor, err := edwards.AdaptorSign(curve, priv.Serialize(), nonceScalar, msg, tPoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := edwards.AdaptorVerify(curve, pub, msg, tPoint, adaptor); err != nil {
+		return nil, err
+	}
+
+	recordedTSecret := tSecret
+	if corrupt {
+		corrupted := make([]byte, len(tSecret))
+		copy(corrupted, tSecret)
+		corrupted[0] ^= 1
+		recordedTSecret = corrupted
+	}
+	completed := edwards.AdaptorAdapt(curve, adaptor, recordedTSecret, tPoint)
+
+	signers := []signerVector{{
+		Privkey:      hex.EncodeToString(privScalar),
+		PrivateNonce: hex.EncodeToString(nonceScalar),
+	}}
+
+	return &vector{
+		Protocol:          protocolAdaptor,
+		Msg:               hex.EncodeToString(msg),
+		Signers:           signers,
+		T:                 hex.EncodeToString(tPoint.Serialize()),
+		TSecret:           hex.EncodeToString(recordedTSecret),
+		AdaptorR:          hex.EncodeToString(adaptor.GetR().Serialize()),
+		AdaptorS:          hex.EncodeToString(adaptor.GetS().Bytes()),
+		CombinedSignature: hex.EncodeToString(completed.Serialize()),
+		Corrupt:           corrupt,
+	}, nil
+}
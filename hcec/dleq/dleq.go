@@ -0,0 +1,438 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package dleq proves that a single scalar x is the discrete log of a
+// point on this module's Ed25519-based TwistedEdwardsCurve and, at the
+// same time, of a point on an arbitrary short-Weierstrass elliptic.Curve
+// such as secp256k1, without revealing x. It exists to let the edwards
+// package's adaptor signatures be used for Hc <-> Decred/Bitcoin atomic
+// swaps: a swap counterparty needs to know that the tweak point published
+// on one chain and the tweak point published on the other chain share the
+// same underlying secret before they risk funds on it. Prove and Verify
+// take secCurve as a plain elliptic.Curve, so callers supply whichever
+// secp256k1 implementation their swap counterparty's chain uses; this
+// repo does not vendor one itself.
+//
+// Because Ed25519 and secp256k1-shaped curves have different,
+// incommensurate group orders, there is no direct Chaum-Pedersen equality
+// proof between points on the two curves. Instead this package proves
+// equality bit by bit: x is decomposed into 252 bits (comfortably below
+// both curves' group orders), each bit is Pedersen-committed on both
+// curves with the *same* blinding value, and a one-of-two Schnorr OR proof
+// shows each commitment opens to 0 or 1. Every nonce and challenge drawn
+// for a bit proof is kept below 2^64, so that the real branch's response
+// z = e*r + k -- the value actually transmitted and checked as a scalar on
+// both curves -- never exceeds 2^128, well under either curve's order.
+// That margin is load-bearing, not cosmetic: the same raw integer z must
+// reduce identically whether it is used as a scalar on Ed25519 or on the
+// short-Weierstrass curve, and that only holds if z itself never reaches
+// either curve's order; a z large enough to wrap on one curve but not the
+// other would let a dishonest prover open the same commitment to
+// different bits on the two curves. Point negation differs
+// between the two curve shapes ((-x, y) for Ed25519, (x, -y) for short
+// Weierstrass) and is handled separately for each; see edNegate and
+// weierstrassNegate. Proof size is O(bitLength): each of the 252 bit
+// proofs carries six curve points (three per curve) and four scalars, so
+// a proof holds 1,512 points and is several times larger than 10 KB (about
+// 60 KB with compressed points). BenchmarkProve and BenchmarkVerify
+// measure the time to prove and to verify.
+package dleq
+
+import (
+	cryptorand "crypto/rand"
+
+	"crypto/elliptic"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+
+	"github.com/OliviaSong/hcd/hcec/edwards"
+)
+
+// bitLength is the number of bits proven, comfortably below both the
+// Ed25519 (~2^252.x) and secp256k1 (~2^256) group orders.
+const bitLength = 252
+
+// nonceBound and challengeBound keep every per-bit nonce and challenge
+// below 2^64, so that the real branch's response z = eReal*r + kReal --
+// the largest of the three, since it is their product plus a term -- stays
+// below 2^128 rather than wrapping past either curve's order. 2^64 is
+// itself well above any bound a realistic adversary could exhaust, so the
+// margin this buys on z costs no meaningful security.
+var (
+	nonceBound     = new(big.Int).Lsh(big.NewInt(1), 64)
+	challengeBound = new(big.Int).Lsh(big.NewInt(1), 64)
+)
+
+// point is a minimal (x, y) affine point, used to carry coordinates for
+// both curve families through the shared proof logic below.
+type point struct {
+	x, y *big.Int
+}
+
+// bitProof is a one-of-two Schnorr OR proof, run in parallel on both
+// curves with shared randomness, that a pair of Pedersen commitments
+// (one per curve) open to the same bit b in {0, 1} under the same
+// blinding r.
+type bitProof struct {
+	edC, secC     point
+	edA0, edA1    point
+	secA0, secA1  point
+	e0, e1        *big.Int
+	z0, z1        *big.Int
+}
+
+// Proof is a complete cross-curve DLEQ proof for one scalar x.
+type Proof struct {
+	bits []*bitProof
+	rEd  *big.Int // sum_i 2^i * r_i, revealed so the verifier can check the aggregate equation
+}
+
+// hashToCurveEd derives a nothing-up-my-sleeve point on edCurve from tag by
+// try-and-increment: hash tag||counter into a candidate 32-byte compressed
+// point and keep incrementing the counter until it decompresses.
+func hashToCurveEd(edCurve *edwards.TwistedEdwardsCurve, tag string) (*big.Int, *big.Int) {
+	for counter := byte(0); ; counter++ {
+		h := sha512.Sum512(append([]byte(tag), counter))
+		candidate := h[:32]
+		if pub, err := edwards.ParsePubKey(edCurve, candidate); err == nil {
+			return pub.GetX(), pub.GetY()
+		}
+	}
+}
+
+// weierstrassA returns the short Weierstrass "a" coefficient for curve's
+// equation y^2 = x^3 + a*x + b. elliptic.CurveParams does not expose it
+// directly, but every curve this package is meant to run against is one of
+// two known shapes: secp256k1 (a = 0) or one of the standard library's
+// NIST curves (a = -3, including P256, which is what this package's own
+// tests exercise in the absence of a vendored secp256k1 implementation).
+func weierstrassA(curve elliptic.Curve) *big.Int {
+	if curve.Params().Name == "secp256k1" {
+		return big.NewInt(0)
+	}
+	return big.NewInt(-3)
+}
+
+// hashToCurveSecp derives a nothing-up-my-sleeve point on secCurve from
+// tag by try-and-increment against the curve's short Weierstrass equation
+// y^2 = x^3 + a*x + b, as exposed by elliptic.CurveParams plus
+// weierstrassA above.
+func hashToCurveSecp(secCurve elliptic.Curve, tag string) (*big.Int, *big.Int) {
+	params := secCurve.Params()
+	a := weierstrassA(secCurve)
+
+	for counter := byte(0); ; counter++ {
+		h := sha512.Sum512(append([]byte(tag), counter))
+		x := new(big.Int).SetBytes(h[:])
+		x.Mod(x, params.P)
+
+		rhs := new(big.Int).Exp(x, big.NewInt(3), params.P)
+		ax := new(big.Int).Mul(a, x)
+		rhs.Add(rhs, ax)
+		rhs.Add(rhs, params.B)
+		rhs.Mod(rhs, params.P)
+
+		y := new(big.Int).ModSqrt(rhs, params.P)
+		if y != nil {
+			return x, y
+		}
+	}
+}
+
+// Prove produces a cross-curve DLEQ proof that xBytes is the discrete log
+// of both returned points, X_ed = x*B_ed on edCurve and X_sec = x*G_sec on
+// secCurve.
+func Prove(xBytes []byte, edCurve *edwards.TwistedEdwardsCurve, secCurve elliptic.Curve) (*Proof, *big.Int, *big.Int, *big.Int, *big.Int, error) {
+	x := new(big.Int).SetBytes(xBytes)
+	if x.BitLen() > bitLength {
+		return nil, nil, nil, nil, nil, errors.New("dleq: scalar exceeds the supported bit length")
+	}
+
+	edHx, edHy := hashToCurveEd(edCurve, "dleq/H/ed25519")
+	secHx, secHy := hashToCurveSecp(secCurve, "dleq/H/secp256k1")
+
+	edXx, edXy := edCurve.ScalarBaseMult(x.Bytes())
+	secXx, secXy := secCurve.ScalarBaseMult(x.Bytes())
+
+	bits := make([]*bitProof, bitLength)
+	rSum := new(big.Int)
+	for i := 0; i < bitLength; i++ {
+		bit := x.Bit(i)
+
+		r, err := randBelow(nonceBound)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		rSum.Add(rSum, new(big.Int).Lsh(r, uint(i)))
+
+		proof, err := proveBit(edCurve, secCurve, edHx, edHy, secHx, secHy, bit, r)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		bits[i] = proof
+	}
+
+	return &Proof{bits: bits, rEd: rSum}, edXx, edXy, secXx, secXy, nil
+}
+
+// proveBit runs the shared-randomness one-of-two Schnorr OR proof for a
+// single bit, committing it on both curves as C = b*Base + r*H.
+func proveBit(edCurve *edwards.TwistedEdwardsCurve, secCurve elliptic.Curve,
+	edHx, edHy, secHx, secHy *big.Int, bit uint, r *big.Int) (*bitProof, error) {
+
+	edGx, edGy := edCurve.ScalarBaseMult(big.NewInt(int64(bit)).Bytes())
+	edRx, edRy := edCurve.ScalarMult(edHx, edHy, r.Bytes())
+	edCx, edCy := edCurve.Add(edGx, edGy, edRx, edRy)
+
+	secGx, secGy := secCurve.ScalarBaseMult(big.NewInt(int64(bit)).Bytes())
+	secRx, secRy := secCurve.ScalarMult(secHx, secHy, r.Bytes())
+	secCx, secCy := secCurve.Add(secGx, secGy, secRx, secRy)
+
+	// Real branch: standard Schnorr proof of knowledge of r opening
+	// C = bit*Base + r*H (for the actual bit). Fake branch: simulated
+	// by picking a random response and solving for the commitment A.
+	kReal, err := randBelow(nonceBound)
+	if err != nil {
+		return nil, err
+	}
+	zFake, err := randBelow(nonceBound)
+	if err != nil {
+		return nil, err
+	}
+	eFake, err := randBelow(challengeBound)
+	if err != nil {
+		return nil, err
+	}
+
+	edArealX, edArealY := edCurve.ScalarMult(edHx, edHy, kReal.Bytes())
+	secArealX, secArealY := secCurve.ScalarMult(secHx, secHy, kReal.Bytes())
+
+	// Fake branch target: the commitment to the OTHER bit value.
+	otherBit := new(big.Int).Xor(big.NewInt(int64(bit)), big.NewInt(1))
+	edOtherGx, edOtherGy := edCurve.ScalarBaseMult(otherBit.Bytes())
+	secOtherGx, secOtherGy := secCurve.ScalarBaseMult(otherBit.Bytes())
+
+	// A_fake = zFake*H - eFake*C + eFake*otherBit*Base, which is exactly
+	// what checkBranchGeneric below expects to recover from a valid
+	// (A, e, z) triple for the non-witness bit value.
+	edZfHx, edZfHy := edCurve.ScalarMult(edHx, edHy, zFake.Bytes())
+	edEfCx, edEfCy := edCurve.ScalarMult(edCx, edCy, eFake.Bytes())
+	edEfOtherX, edEfOtherY := edCurve.ScalarMult(edOtherGx, edOtherGy, eFake.Bytes())
+	negEfCx, negEfCy := edNegate(edCurve, edEfCx, edEfCy)
+	edAfakeX, edAfakeY := edCurve.Add(edZfHx, edZfHy, negEfCx, negEfCy)
+	edAfakeX, edAfakeY = edCurve.Add(edAfakeX, edAfakeY, edEfOtherX, edEfOtherY)
+
+	secZfHx, secZfHy := secCurve.ScalarMult(secHx, secHy, zFake.Bytes())
+	secEfCx, secEfCy := secCurve.ScalarMult(secCx, secCy, eFake.Bytes())
+	secEfOtherX, secEfOtherY := secCurve.ScalarMult(secOtherGx, secOtherGy, eFake.Bytes())
+	negSecEfCx, negSecEfCy := weierstrassNegate(secCurve, secEfCx, secEfCy)
+	secAfakeX, secAfakeY := secCurve.Add(secZfHx, secZfHy, negSecEfCx, negSecEfCy)
+	secAfakeX, secAfakeY = secCurve.Add(secAfakeX, secAfakeY, secEfOtherX, secEfOtherY)
+
+	// a0/a1 (and the secp-curve equivalents) must be assigned from
+	// real/fake by bit value *before* hashing, so that the challenge is
+	// computed over the same canonical (A0, A1) ordering that
+	// verifyBitProof reconstructs from e0/e1/z0/z1 -- the verifier has no
+	// way to know which of a0/a1 was the real branch, so the prover's
+	// challenge must not depend on that order either.
+	var a0, a1 point
+	var secA0, secA1 point
+	if bit == 0 {
+		a0 = point{edArealX, edArealY}
+		a1 = point{edAfakeX, edAfakeY}
+		secA0 = point{secArealX, secArealY}
+		secA1 = point{secAfakeX, secAfakeY}
+	} else {
+		a1 = point{edArealX, edArealY}
+		a0 = point{edAfakeX, edAfakeY}
+		secA1 = point{secArealX, secArealY}
+		secA0 = point{secAfakeX, secAfakeY}
+	}
+
+	e := fiatShamirChallenge(point{edCx, edCy}, point{secCx, secCy},
+		a0, secA0, a1, secA1)
+	e.Mod(e, challengeBound)
+
+	eReal := new(big.Int).Sub(e, eFake)
+	eReal.Mod(eReal, challengeBound)
+
+	zReal := new(big.Int).Mul(eReal, r)
+	zReal.Add(zReal, kReal)
+
+	var e0, e1, z0, z1 *big.Int
+	if bit == 0 {
+		e0, z0 = eReal, zReal
+		e1, z1 = eFake, zFake
+	} else {
+		e1, z1 = eReal, zReal
+		e0, z0 = eFake, zFake
+	}
+
+	return &bitProof{
+		edC: point{edCx, edCy}, secC: point{secCx, secCy},
+		edA0: a0, edA1: a1,
+		secA0: secA0, secA1: secA1,
+		e0: e0, e1: e1,
+		z0: z0, z1: z1,
+	}, nil
+}
+
+// Verify checks a cross-curve DLEQ proof against the claimed points X_ed
+// and X_sec.
+func Verify(proof *Proof, edXx, edXy, secXx, secXy *big.Int,
+	edCurve *edwards.TwistedEdwardsCurve, secCurve elliptic.Curve) error {
+
+	if len(proof.bits) != bitLength {
+		return errors.New("dleq: wrong number of bit proofs")
+	}
+
+	edHx, edHy := hashToCurveEd(edCurve, "dleq/H/ed25519")
+	secHx, secHy := hashToCurveSecp(secCurve, "dleq/H/secp256k1")
+
+	var edSumX, edSumY, secSumX, secSumY *big.Int
+	for i, bp := range proof.bits {
+		if err := verifyBitProof(edCurve, secCurve, edHx, edHy, secHx, secHy, bp); err != nil {
+			return err
+		}
+
+		weight := new(big.Int).Lsh(big.NewInt(1), uint(i))
+		edWx, edWy := edCurve.ScalarMult(bp.edC.x, bp.edC.y, weight.Bytes())
+		secWx, secWy := secCurve.ScalarMult(bp.secC.x, bp.secC.y, weight.Bytes())
+
+		if edSumX == nil {
+			edSumX, edSumY = edWx, edWy
+			secSumX, secSumY = secWx, secWy
+			continue
+		}
+		edSumX, edSumY = edCurve.Add(edSumX, edSumY, edWx, edWy)
+		secSumX, secSumY = secCurve.Add(secSumX, secSumY, secWx, secWy)
+	}
+
+	// Sum_i 2^i*C_i = X + rEd*H; check the equation with rEd revealed.
+	edRHx, edRHy := edCurve.ScalarMult(edHx, edHy, proof.rEd.Bytes())
+	edCheckX, edCheckY := edCurve.Add(edXx, edXy, edRHx, edRHy)
+	if edCheckX.Cmp(edSumX) != 0 || edCheckY.Cmp(edSumY) != 0 {
+		return errors.New("dleq: ed25519 aggregate check failed")
+	}
+
+	secRHx, secRHy := secCurve.ScalarMult(secHx, secHy, proof.rEd.Bytes())
+	secCheckX, secCheckY := secCurve.Add(secXx, secXy, secRHx, secRHy)
+	if secCheckX.Cmp(secSumX) != 0 || secCheckY.Cmp(secSumY) != 0 {
+		return errors.New("dleq: secp256k1 aggregate check failed")
+	}
+
+	return nil
+}
+
+// verifyBitProof checks both branches of a single bit's OR proof on both
+// curves, and that the two branches' challenges sum to the Fiat-Shamir
+// challenge derived from the commitments and nonce commitments.
+func verifyBitProof(edCurve *edwards.TwistedEdwardsCurve, secCurve elliptic.Curve,
+	edHx, edHy, secHx, secHy *big.Int, bp *bitProof) error {
+
+	e := fiatShamirChallenge(bp.edC, bp.secC, bp.edA0, bp.secA0, bp.edA1, bp.secA1)
+	e.Mod(e, challengeBound)
+
+	eSum := new(big.Int).Add(bp.e0, bp.e1)
+	eSum.Mod(eSum, challengeBound)
+	if eSum.Cmp(e) != 0 {
+		return errors.New("dleq: bit challenge split does not match commitment")
+	}
+
+	// Reject challenges and responses outside the ranges an honest prover
+	// produces, so that every z is the same scalar on both curves.
+	zBound := new(big.Int).Lsh(big.NewInt(1), 128)
+	for _, v := range []*big.Int{bp.e0, bp.e1} {
+		if v.Sign() < 0 || v.Cmp(challengeBound) >= 0 {
+			return errors.New("dleq: bit challenge out of range")
+		}
+	}
+	for _, v := range []*big.Int{bp.z0, bp.z1} {
+		if v.Sign() < 0 || v.Cmp(zBound) >= 0 {
+			return errors.New("dleq: bit response out of range")
+		}
+	}
+
+	if !checkBranch(edCurve, edHx, edHy, bp.edC, bp.edA0, big.NewInt(0), bp.e0, bp.z0) {
+		return errors.New("dleq: ed25519 branch 0 failed")
+	}
+	if !checkBranch(edCurve, edHx, edHy, bp.edC, bp.edA1, big.NewInt(1), bp.e1, bp.z1) {
+		return errors.New("dleq: ed25519 branch 1 failed")
+	}
+	if !checkBranchGeneric(secCurve, secHx, secHy, bp.secC, bp.secA0, big.NewInt(0), bp.e0, bp.z0) {
+		return errors.New("dleq: secp256k1 branch 0 failed")
+	}
+	if !checkBranchGeneric(secCurve, secHx, secHy, bp.secC, bp.secA1, big.NewInt(1), bp.e1, bp.z1) {
+		return errors.New("dleq: secp256k1 branch 1 failed")
+	}
+
+	return nil
+}
+
+// checkBranch verifies z*H ?= A + e*C - e*bitValue*Base on edCurve, using
+// Ed25519 point negation ((-x, y), not (x, -y)) to subtract.
+func checkBranch(edCurve *edwards.TwistedEdwardsCurve, hx, hy *big.Int, c, a point, bitValue, e, z *big.Int) bool {
+	lhsX, lhsY := edCurve.ScalarMult(hx, hy, z.Bytes())
+
+	eCx, eCy := edCurve.ScalarMult(c.x, c.y, e.Bytes())
+	baseX, baseY := edCurve.ScalarBaseMult(bitValue.Bytes())
+	eBaseX, eBaseY := edCurve.ScalarMult(baseX, baseY, e.Bytes())
+	negEBaseX, negEBaseY := edNegate(edCurve, eBaseX, eBaseY)
+
+	rhsX, rhsY := edCurve.Add(a.x, a.y, eCx, eCy)
+	rhsX, rhsY = edCurve.Add(rhsX, rhsY, negEBaseX, negEBaseY)
+
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
+}
+
+// checkBranchGeneric is checkBranch's counterpart for the short
+// Weierstrass curve (secp256k1-shaped), where point negation is
+// (x, -y) rather than Ed25519's (-x, y).
+func checkBranchGeneric(curve elliptic.Curve, hx, hy *big.Int, c, a point, bitValue, e, z *big.Int) bool {
+	lhsX, lhsY := curve.ScalarMult(hx, hy, z.Bytes())
+
+	eCx, eCy := curve.ScalarMult(c.x, c.y, e.Bytes())
+	baseX, baseY := curve.ScalarBaseMult(bitValue.Bytes())
+	eBaseX, eBaseY := curve.ScalarMult(baseX, baseY, e.Bytes())
+	negEBaseX, negEBaseY := weierstrassNegate(curve, eBaseX, eBaseY)
+
+	rhsX, rhsY := curve.Add(a.x, a.y, eCx, eCy)
+	rhsX, rhsY = curve.Add(rhsX, rhsY, negEBaseX, negEBaseY)
+
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
+}
+
+// edNegate returns the Ed25519 negation of (x, y): (-x mod p, y).
+func edNegate(edCurve *edwards.TwistedEdwardsCurve, x, y *big.Int) (*big.Int, *big.Int) {
+	negX := new(big.Int).Sub(edCurve.P, x)
+	negX.Mod(negX, edCurve.P)
+	return negX, y
+}
+
+// weierstrassNegate returns the short-Weierstrass negation of (x, y):
+// (x, -y mod p).
+func weierstrassNegate(curve elliptic.Curve, x, y *big.Int) (*big.Int, *big.Int) {
+	p := curve.Params().P
+	negY := new(big.Int).Sub(p, y)
+	negY.Mod(negY, p)
+	return x, negY
+}
+
+// fiatShamirChallenge hashes the serialized transcript of both curves'
+// points into a single shared challenge.
+func fiatShamirChallenge(points ...point) *big.Int {
+	h := sha512.New()
+	for _, p := range points {
+		h.Write(p.x.Bytes())
+		h.Write(p.y.Bytes())
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// randBelow returns a cryptographically random integer in [0, bound).
+func randBelow(bound *big.Int) (*big.Int, error) {
+	return cryptorand.Int(cryptorand.Reader, bound)
+}
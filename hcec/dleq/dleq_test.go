@@ -0,0 +1,138 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dleq
+
+import (
+	cryptorand "crypto/rand"
+
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/OliviaSong/hcd/hcec/edwards"
+)
+
+func testScalar(t *testing.T) []byte {
+	t.Helper()
+	x, err := cryptorand.Int(cryptorand.Reader, nonceBound)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	return x.Bytes()
+}
+
+// TestProveVerify checks that a proof generated over matching Ed25519 and
+// short-Weierstrass points verifies, and that the scalar really is shared
+// between the two returned points. It exercises the proof against
+// elliptic.P256 rather than secp256k1, since this repo has no vendored
+// secp256k1 implementation; Prove/Verify accept any elliptic.Curve, and
+// swap partners would pass a real secp256k1 curve at this same call site.
+func TestProveVerify(t *testing.T) {
+	edCurve := new(edwards.TwistedEdwardsCurve)
+	edCurve.InitParam25519()
+	secCurve := elliptic.P256()
+
+	x := testScalar(t)
+
+	proof, edXx, edXy, secXx, secXy, err := Prove(x, edCurve, secCurve)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	if err := Verify(proof, edXx, edXy, secXx, secXy, edCurve, secCurve); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+}
+
+// TestProveVerifyFullWidthScalar exercises a scalar near the top of the
+// supported 252-bit range rather than testScalar's ~2^64 scalars, so the
+// aggregate equation and every per-bit proof are exercised with set bits
+// above bit 64, including the top bit of the 252-bit decomposition.
+func TestProveVerifyFullWidthScalar(t *testing.T) {
+	edCurve := new(edwards.TwistedEdwardsCurve)
+	edCurve.InitParam25519()
+	secCurve := elliptic.P256()
+
+	x := new(big.Int).Lsh(big.NewInt(1), bitLength-1)
+	random, err := cryptorand.Int(cryptorand.Reader, x)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	x.Or(x, random)
+
+	proof, edXx, edXy, secXx, secXy, err := Prove(x.Bytes(), edCurve, secCurve)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	if err := Verify(proof, edXx, edXy, secXx, secXy, edCurve, secCurve); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+}
+
+// TestVerifyRejectsMismatchedPoints checks that the proof fails to verify
+// against a point that does not share the proven scalar.
+func TestVerifyRejectsMismatchedPoints(t *testing.T) {
+	edCurve := new(edwards.TwistedEdwardsCurve)
+	edCurve.InitParam25519()
+	secCurve := elliptic.P256()
+
+	proof, edXx, edXy, _, _, err := Prove(testScalar(t), edCurve, secCurve)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	otherSecX, otherSecY := secCurve.ScalarBaseMult(testScalar(t))
+
+	if err := Verify(proof, edXx, edXy, otherSecX, otherSecY, edCurve, secCurve); err == nil {
+		t.Fatalf("expected verification failure for mismatched secp256k1 point")
+	}
+}
+
+// BenchmarkProve measures the cost of producing a full 252-bit cross-curve
+// DLEQ proof; the package doc gives the proof's size.
+func BenchmarkProve(b *testing.B) {
+	edCurve := new(edwards.TwistedEdwardsCurve)
+	edCurve.InitParam25519()
+	secCurve := elliptic.P256()
+
+	x, err := cryptorand.Int(cryptorand.Reader, nonceBound)
+	if err != nil {
+		b.Fatalf("unexpected error %s, ", err)
+	}
+	xBytes := x.Bytes()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, _, _, err := Prove(xBytes, edCurve, secCurve); err != nil {
+			b.Fatalf("unexpected error %s, ", err)
+		}
+	}
+}
+
+// BenchmarkVerify measures the cost of verifying a full 252-bit
+// cross-curve DLEQ proof.
+func BenchmarkVerify(b *testing.B) {
+	edCurve := new(edwards.TwistedEdwardsCurve)
+	edCurve.InitParam25519()
+	secCurve := elliptic.P256()
+
+	x, err := cryptorand.Int(cryptorand.Reader, nonceBound)
+	if err != nil {
+		b.Fatalf("unexpected error %s, ", err)
+	}
+	proof, edXx, edXy, secXx, secXy, err := Prove(x.Bytes(), edCurve, secCurve)
+	if err != nil {
+		b.Fatalf("unexpected error %s, ", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Verify(proof, edXx, edXy, secXx, secXy, edCurve, secCurve); err != nil {
+			b.Fatalf("unexpected error %s, ", err)
+		}
+	}
+}
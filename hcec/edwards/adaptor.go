@@ -0,0 +1,122 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"errors"
+	"math/big"
+)
+
+// AdaptorSig is an Ed25519/Schnorr adaptor signature: a signature whose
+// challenge commits to R + T rather than R (while AdaptorVerify checks its
+// scalar against R itself), and that only
+// becomes a valid signature once adapted with the discrete log t of the
+// tweak point T = t*B. AdaptorExtract recovers t from the gap between an
+// adaptor signature and its completed counterpart, which is the mechanism
+// that makes cross-chain atomic swaps built on this primitive atomic: the
+// same reveal of t that completes one chain's signature also reveals t to
+// the counterparty on the other chain.
+type AdaptorSig struct {
+	r *PublicKey // R, the signer's original nonce commitment (not R+T)
+	s *big.Int   // s' = r + c*a mod N
+}
+
+// GetR returns the adaptor signature's nonce commitment R.
+func (a *AdaptorSig) GetR() *PublicKey {
+	return a.r
+}
+
+// GetS returns the adaptor signature's incomplete scalar s'.
+func (a *AdaptorSig) GetS() *big.Int {
+	return a.s
+}
+
+// AdaptorSign produces an adaptor signature over msg that is only
+// completable by a party who knows the discrete log t of the tweak point
+// T = t*B. priv and nonce are the signer's private scalar and per-session
+// nonce scalar, both serialized the same way as the rest of this package's
+// Schnorr API. The challenge binds the tweaked commitment R' = R + T, but
+// the published scalar s' = r + c*a mod N omits t, so the adaptor
+// signature alone does not verify as a standard Schnorr signature.
+func AdaptorSign(curve *TwistedEdwardsCurve, priv []byte, nonce []byte, msg []byte, t *PublicKey) (*AdaptorSig, error) {
+	privKey, pubKey, err := PrivKeyFromScalar(curve, priv)
+	if err != nil {
+		return nil, err
+	}
+	nonceKey, nonceCommit, err := PrivKeyFromScalar(curve, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	tweakedX, tweakedY := curve.Add(nonceCommit.GetX(), nonceCommit.GetY(), t.GetX(), t.GetY())
+	tweakedR := NewPublicKey(curve, tweakedX, tweakedY)
+
+	c := schnorrChallenge(curve, tweakedR, pubKey, msg)
+
+	s := new(big.Int).Mul(c, privKey.GetD())
+	s.Add(s, nonceKey.GetD())
+	s.Mod(s, curve.N)
+
+	return &AdaptorSig{r: nonceCommit, s: s}, nil
+}
+
+// AdaptorVerify checks that adaptor is a validly formed adaptor signature
+// over msg under pub with tweak point t, without requiring knowledge of
+// the tweak secret: it checks s'*B == R + c*P, where c is computed against
+// the tweaked commitment R' = R + T exactly as in AdaptorSign.
+func AdaptorVerify(curve *TwistedEdwardsCurve, pub *PublicKey, msg []byte, t *PublicKey, adaptor *AdaptorSig) error {
+	tweakedX, tweakedY := curve.Add(adaptor.r.GetX(), adaptor.r.GetY(), t.GetX(), t.GetY())
+	tweakedR := NewPublicKey(curve, tweakedX, tweakedY)
+
+	c := schnorrChallenge(curve, tweakedR, pub, msg)
+
+	lhsX, lhsY := curve.ScalarBaseMult(adaptor.s.Bytes())
+
+	cpX, cpY := curve.ScalarMult(pub.GetX(), pub.GetY(), c.Bytes())
+	rhsX, rhsY := curve.Add(adaptor.r.GetX(), adaptor.r.GetY(), cpX, cpY)
+
+	if lhsX.Cmp(rhsX) != 0 || lhsY.Cmp(rhsY) != 0 {
+		return errors.New("edwards: invalid adaptor signature")
+	}
+	return nil
+}
+
+// AdaptorAdapt completes an adaptor signature into a standard, verifiable
+// Ed25519/Schnorr signature using the tweak secret t (the swap secret).
+// The completed signature uses the tweaked commitment R' = R + T, and its
+// scalar is s = s' + t mod N.
+func AdaptorAdapt(curve *TwistedEdwardsCurve, adaptor *AdaptorSig, t []byte, tPoint *PublicKey) *Signature {
+	tScalar := new(big.Int).SetBytes(t)
+
+	tweakedX, tweakedY := curve.Add(adaptor.r.GetX(), adaptor.r.GetY(), tPoint.GetX(), tPoint.GetY())
+
+	s := new(big.Int).Add(adaptor.s, tScalar)
+	s.Mod(s, curve.N)
+
+	rBytes := BigIntPointToEncodedBytes(tweakedX, tweakedY)
+	r := new(big.Int).SetBytes(rBytes[:])
+
+	return NewSignature(r, s)
+}
+
+// AdaptorExtract recovers the tweak secret t given an adaptor signature and
+// the completed signature that a counterparty published after adapting it:
+// t = s - s' mod N. This is the step that makes an Ed25519-based atomic
+// swap atomic — observing the completed signature on one chain reveals t,
+// which can then complete the adaptor signature on the other chain.
+func AdaptorExtract(curve *TwistedEdwardsCurve, adaptor *AdaptorSig, full *Signature) ([]byte, error) {
+	adaptorRBytes := BigIntPointToEncodedBytes(adaptor.r.GetX(), adaptor.r.GetY())
+	adaptorR := new(big.Int).SetBytes(adaptorRBytes[:])
+	if full.GetR().Cmp(adaptorR) == 0 {
+		return nil, errors.New("edwards: full signature does not look tweaked relative to adaptor")
+	}
+
+	t := new(big.Int).Sub(full.GetS(), adaptor.s)
+	t.Mod(t, curve.N)
+
+	tBytes := copyBytes(t.Bytes())
+	return tBytes[:], nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// adaptorTestVectorHex is the JSON-encodable form of an adaptor-signature
+// test vector, mirroring the style of ThresholdTestVectorHex.
+type adaptorTestVectorHex struct {
+	Privkey string `json:"privkey"`
+	Nonce   string `json:"nonce"`
+	TSecret string `json:"tSecret"`
+	Msg     string `json:"msg"`
+}
+
+// TestAdaptorSignVerifyAdaptExtract round-trips a single adaptor signature
+// through sign, verify, adapt and extract, confirming that the extracted
+// tweak secret matches the one used to complete the signature.
+func TestAdaptorSignVerifyAdaptExtract(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	vectorJSON := []byte(`{
+		"privkey": "000000000000000000000000000000000000000000000000000000000000000a",
+		"nonce":   "000000000000000000000000000000000000000000000000000000000000000b",
+		"tSecret": "000000000000000000000000000000000000000000000000000000000000000c",
+		"msg":     "d04b98f48e8f8bcc15c6ae5ac050801cd6dcfd428fb5f9e65c4e16e7807340fa"
+	}`)
+	var vector adaptorTestVectorHex
+	if err := json.Unmarshal(vectorJSON, &vector); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	priv, _ := hex.DecodeString(vector.Privkey)
+	nonce, _ := hex.DecodeString(vector.Nonce)
+	tSecret, _ := hex.DecodeString(vector.TSecret)
+	msg, _ := hex.DecodeString(vector.Msg)
+
+	_, pub, err := PrivKeyFromScalar(curve, priv)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	_, tPoint, err := PrivKeyFromScalar(curve, tSecret)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	adaptor, err := AdaptorSign(curve, priv, nonce, msg, tPoint)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	if err := AdaptorVerify(curve, pub, msg, tPoint, adaptor); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	full := AdaptorAdapt(curve, adaptor, tSecret, tPoint)
+	if !Verify(pub, msg, full.GetR(), full.GetS()) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	extracted, err := AdaptorExtract(curve, adaptor, full)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	extractedScalar := new(big.Int).SetBytes(extracted)
+	tSecretScalar := new(big.Int).SetBytes(tSecret)
+	if extractedScalar.Cmp(tSecretScalar) != 0 {
+		t.Fatalf("expected %x, got %x", tSecretScalar, extractedScalar)
+	}
+}
+
+// TestAdaptorSwapWorkedExample walks through a simplified two-party atomic
+// swap: Alice publishes an adaptor signature spendable on her own chain's
+// verifier only after Bob reveals t (by completing the matching adaptor
+// signature on his side of the swap). Observing Bob's completed signature
+// lets Alice extract t and complete her own half.
+func TestAdaptorSwapWorkedExample(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	msg, _ := hex.DecodeString(
+		"d04b98f48e8f8bcc15c6ae5ac050801cd6dcfd428fb5f9e65c4e16e7807340fa")
+
+	aliceKeys := randPrivScalarKeyList(curve, 1)
+	aliceNonces := randPrivScalarKeyList(curve, 1)
+	_, alicePub, err := PrivKeyFromScalar(curve, aliceKeys[0].Serialize())
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	swapSecrets := randPrivScalarKeyList(curve, 1)
+	tSecret := swapSecrets[0].Serialize()
+	_, tPoint, err := PrivKeyFromScalar(curve, tSecret[:])
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	// Alice publishes her adaptor signature; Bob (or anyone) can verify
+	// it is well-formed relative to T without learning t.
+	aliceAdaptor, err := AdaptorSign(curve, aliceKeys[0].Serialize(), aliceNonces[0].Serialize(), msg, tPoint)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if err := AdaptorVerify(curve, alicePub, msg, tPoint, aliceAdaptor); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	// Bob, who actually knows t, completes his own matching adaptor
+	// signature and publishes it on-chain. Alice observes it and
+	// extracts t.
+	bobKeys := randPrivScalarKeyList(curve, 1)
+	bobNonces := randPrivScalarKeyList(curve, 1)
+	_, bobPub, err := PrivKeyFromScalar(curve, bobKeys[0].Serialize())
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	bobAdaptor, err := AdaptorSign(curve, bobKeys[0].Serialize(), bobNonces[0].Serialize(), msg, tPoint)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	bobCompleted := AdaptorAdapt(curve, bobAdaptor, tSecret[:], tPoint)
+	if !Verify(bobPub, msg, bobCompleted.GetR(), bobCompleted.GetS()) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	extractedT, err := AdaptorExtract(curve, bobAdaptor, bobCompleted)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	// Alice now completes her own half of the swap with the extracted t.
+	aliceCompleted := AdaptorAdapt(curve, aliceAdaptor, extractedT, tPoint)
+	if !Verify(alicePub, msg, aliceCompleted.GetR(), aliceCompleted.GetS()) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
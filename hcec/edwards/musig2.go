@@ -0,0 +1,168 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"errors"
+	"sort"
+
+	"math/big"
+)
+
+// TaggedHash implements the BIP 340 / MuSig2 style tagged hash construction,
+// adapted to Ed25519's 512-bit hash domain: where BIP 340 uses SHA-256, the
+// tag is hashed with SHA-512 and the 512-bit digest is written twice, so
+// the prefix fills one 1024-bit SHA-512 input block. The resulting hash is
+// SHA-512(SHA-512(tag) || SHA-512(tag) || msgs...).
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha512.Sum512([]byte(tag))
+
+	h := sha512.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+
+	return h.Sum(nil)
+}
+
+// AggregateCoefficient computes the MuSig2 key aggregation coefficient
+// a_i = H_agg_coeff(ell || X_i) mod N for a single signer's serialized
+// pubkey X_i, given the list hash ell produced by AggregatePubKeys. It is
+// exported separately so that signers can recompute their own coefficient
+// without redoing the O(n) aggregation of the whole signer set.
+func AggregateCoefficient(curve *TwistedEdwardsCurve, ell []byte, pubkeyBytes []byte) *big.Int {
+	h := TaggedHash("KeyAgg coefficient", ell, pubkeyBytes)
+
+	a := new(big.Int).SetBytes(h)
+	a.Mod(a, curve.N)
+	return a
+}
+
+// AggregatePubKeys computes the MuSig2 aggregate public key X = Sum a_i*X_i
+// for a lexicographically sorted list of serialized pubkeys L, along with
+// the per-signer coefficients a_i in the same order as the pubkeys
+// argument. Unlike CombinePubkeys, which naively sums the provided keys and
+// is therefore vulnerable to rogue-key attacks (an attacker can choose
+// P_attack = P_honest_sum^-1 * P_real to force an arbitrary aggregate),
+// AggregatePubKeys binds every key into every other key's coefficient via
+// the list hash ell, so no participant can cancel out the others'
+// contributions. CombinePubkeys remains available for compatibility, but
+// it is unsafe to use with untrusted co-signer keys.
+func AggregatePubKeys(curve *TwistedEdwardsCurve, pubkeys []*PublicKey) (*PublicKey, []*big.Int, error) {
+	if len(pubkeys) == 0 {
+		return nil, nil, errors.New("edwards: no public keys provided to aggregate")
+	}
+
+	serialized := make([][]byte, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		ser := pubkey.Serialize()
+		serialized[i] = ser[:]
+	}
+	sort.Slice(serialized, func(i, j int) bool {
+		return bytes.Compare(serialized[i], serialized[j]) < 0
+	})
+
+	list := make([]byte, 0, len(serialized)*32)
+	for _, ser := range serialized {
+		list = append(list, ser...)
+	}
+	ell := TaggedHash("KeyAgg list", list)
+
+	coefficients := make([]*big.Int, len(pubkeys))
+	var aggX, aggY *big.Int
+	for i, pubkey := range pubkeys {
+		ser := pubkey.Serialize()
+		a := AggregateCoefficient(curve, ell, ser[:])
+		coefficients[i] = a
+
+		px, py := curve.ScalarMult(pubkey.GetX(), pubkey.GetY(), a.Bytes())
+		if aggX == nil {
+			aggX, aggY = px, py
+			continue
+		}
+		aggX, aggY = curve.Add(aggX, aggY, px, py)
+	}
+
+	return NewPublicKey(curve, aggX, aggY), coefficients, nil
+}
+
+// MuSig2PartialSign produces a single signer's partial signature over msg
+// under the MuSig2 protocol. aggPubBytes and pubNonceSumBytes are the
+// serialized aggregate pubkey and aggregate public nonce respectively, and
+// coefficient is this signer's a_i as returned by AggregatePubKeys. The
+// partial signature is s_i = k_i + H(R||X||m)*a_i*x_i mod N, which
+// SchnorrCombineSigs/MuSig2CombineSigs sum into the final signature exactly
+// as with the plain threshold scheme.
+func MuSig2PartialSign(curve *TwistedEdwardsCurve, msg []byte, privScalar []byte,
+	aggPubBytes []byte, coefficient *big.Int, privNonceScalar []byte,
+	pubNonceSumBytes []byte) (*big.Int, *big.Int, error) {
+
+	priv, _, err := PrivKeyFromScalar(curve, privScalar)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, _, err := PrivKeyFromScalar(curve, privNonceScalar)
+	if err != nil {
+		return nil, nil, err
+	}
+	aggPub, err := ParsePubKey(curve, aggPubBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubNonceSum, err := ParsePubKey(curve, pubNonceSumBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := schnorrChallenge(curve, pubNonceSum, aggPub, msg)
+
+	s := new(big.Int).Mul(c, coefficient)
+	s.Mul(s, priv.GetD())
+	s.Add(s, nonce.GetD())
+	s.Mod(s, curve.N)
+
+	rBytes := BigIntPointToEncodedBytes(pubNonceSum.GetX(), pubNonceSum.GetY())
+	r := new(big.Int).SetBytes(rBytes[:])
+
+	return r, s, nil
+}
+
+// MuSig2CombineSigs sums a set of MuSig2 partial signatures, all of which
+// must share the same aggregate public nonce R, into the final signature.
+// The arithmetic is identical to SchnorrCombineSigs; it is re-exposed under
+// the MuSig2 name so callers of the new API are not required to reach back
+// into the plain threshold path.
+func MuSig2CombineSigs(curve *TwistedEdwardsCurve, sigs []*Signature) (*Signature, error) {
+	return SchnorrCombineSigs(curve, sigs)
+}
+
+// MuSig2Verify verifies a combined MuSig2 signature against the aggregate
+// public key produced by AggregatePubKeys. Verification is standard
+// Schnorr verification; no MuSig2-specific logic is required at this step
+// because the key and nonce aggregation already happened before signing.
+func MuSig2Verify(pubkey *PublicKey, msg []byte, sig *Signature) bool {
+	return Verify(pubkey, msg, sig.GetR(), sig.GetS())
+}
+
+// schnorrChallenge computes c = H(R||X||m) reduced mod N using the same
+// hash-to-scalar construction as the plain Schnorr signer/verifier in this
+// package.
+func schnorrChallenge(curve *TwistedEdwardsCurve, r, x *PublicKey, msg []byte) *big.Int {
+	rBytes := BigIntPointToEncodedBytes(r.GetX(), r.GetY())
+	xBytes := BigIntPointToEncodedBytes(x.GetX(), x.GetY())
+
+	h := sha512.New()
+	h.Write(rBytes[:])
+	h.Write(xBytes[:])
+	h.Write(msg)
+
+	c := new(big.Int).SetBytes(h.Sum(nil))
+	return c.Mod(c, curve.N)
+}
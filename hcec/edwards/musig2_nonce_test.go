@@ -0,0 +1,77 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestMuSig2TwoNonce exercises the full two-nonce MuSig2 signing flow for a
+// small signer set: key aggregation, per-signer nonce generation, nonce
+// aggregation, partial signing and combination, and final verification
+// against the aggregate key.
+func TestMuSig2TwoNonce(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	msg, _ := hex.DecodeString(
+		"d04b98f48e8f8bcc15c6ae5ac050801cd6dcfd428fb5f9e65c4e16e7807340fa")
+
+	numSignatories := 5
+	privkeys := randPrivScalarKeyList(curve, numSignatories)
+
+	pubkeys := make([]*PublicKey, numSignatories)
+	for i, priv := range privkeys {
+		_, pub, err := PrivKeyFromScalar(curve, priv.Serialize())
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		pubkeys[i] = pub
+	}
+
+	aggKey, coeffs, err := AggregatePubKeys(curve, pubkeys)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	aggKeyBytes := aggKey.Serialize()
+
+	secNonces := make([]*SecNonces, numSignatories)
+	pubNonces := make([]*PubNonces, numSignatories)
+	for i := range privkeys {
+		sessionID := []byte{byte(i)}
+		sec, pub, err := GenNonces(curve, privkeys[i].Serialize(), aggKeyBytes[:], msg, nil, sessionID)
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		secNonces[i] = sec
+		pubNonces[i] = pub
+	}
+
+	aggNonces, err := AggregateNonces(curve, pubNonces)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	sigs := make([]*Signature, numSignatories)
+	for i := range privkeys {
+		r, s, err := MuSig2Sign(curve, secNonces[i], aggNonces, aggKey, coeffs[i],
+			privkeys[i].Serialize(), msg)
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		sigs[i] = NewSignature(r, s)
+	}
+
+	combined, err := MuSig2CombineSigs(curve, sigs)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	if !MuSig2Verify(aggKey, msg, combined) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
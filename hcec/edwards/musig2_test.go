@@ -0,0 +1,119 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestAggregatePubKeysRogueKeyResistance checks that the naive sum used by
+// CombinePubkeys can be steered to an attacker-chosen aggregate by a rogue
+// key, while AggregatePubKeys, which binds every key into every other key's
+// coefficient, cannot be steered the same way.
+func TestAggregatePubKeysRogueKeyResistance(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	_, honest, err := PrivKeyFromScalar(curve, randPrivScalarKeyList(curve, 1)[0].Serialize())
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	// A rogue participant who can observe the honest key before
+	// contributing their own naively-summed key can cancel it out:
+	// P_attack = P_target - P_honest, so that
+	// CombinePubkeys([honest, attack]) == P_target regardless of the
+	// honest participant's key.
+	_, target, err := PrivKeyFromScalar(curve, randPrivScalarKeyList(curve, 1)[0].Serialize())
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	negOne := new(big.Int).Sub(curve.N, big.NewInt(1))
+	negHonestX, negHonestY := curve.ScalarMult(honest.GetX(), honest.GetY(), negOne.Bytes())
+	attackX, attackY := curve.Add(target.GetX(), target.GetY(), negHonestX, negHonestY)
+	attack := NewPublicKey(curve, attackX, attackY)
+
+	naiveSum := CombinePubkeys(curve, []*PublicKey{honest, attack})
+	if naiveSum.GetX().Cmp(target.GetX()) != 0 || naiveSum.GetY().Cmp(target.GetY()) != 0 {
+		t.Fatalf("expected naive sum to be forgeable to the target key")
+	}
+
+	// The same rogue-key trick does not let the attacker force the
+	// MuSig2 aggregate to equal the target key, because the attacker's
+	// contribution is scaled by a coefficient that depends on the
+	// serialized honest key too.
+	aggPub, coeffs, err := AggregatePubKeys(curve, []*PublicKey{honest, attack})
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if len(coeffs) != 2 {
+		t.Fatalf("expected %v, got %v", 2, len(coeffs))
+	}
+	if aggPub.GetX().Cmp(target.GetX()) == 0 {
+		t.Fatalf("expected MuSig2 aggregate to resist the rogue-key attack")
+	}
+}
+
+// TestMuSig2PartialSignVerify exercises the full single-nonce MuSig2 signing
+// flow -- key aggregation, per-signer partial signing and combination, and
+// final verification against the aggregate key -- to make sure
+// MuSig2PartialSign's output actually lands in the signature encoding that
+// Verify expects.
+func TestMuSig2PartialSignVerify(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	msg := []byte("musig2 partial sign round trip")
+
+	numSignatories := 3
+	privkeys := randPrivScalarKeyList(curve, numSignatories)
+	nonces := randPrivScalarKeyList(curve, numSignatories)
+
+	pubkeys := make([]*PublicKey, numSignatories)
+	pubNonces := make([]*PublicKey, numSignatories)
+	for i := range privkeys {
+		_, pub, err := PrivKeyFromScalar(curve, privkeys[i].Serialize())
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		pubkeys[i] = pub
+
+		_, pubNonce, err := PrivKeyFromScalar(curve, nonces[i].Serialize())
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		pubNonces[i] = pubNonce
+	}
+
+	aggPub, coeffs, err := AggregatePubKeys(curve, pubkeys)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	aggPubBytes := aggPub.Serialize()
+
+	pubNonceSum := CombinePubkeys(curve, pubNonces)
+	pubNonceSumBytes := pubNonceSum.Serialize()
+
+	sigs := make([]*Signature, numSignatories)
+	for i := range privkeys {
+		r, s, err := MuSig2PartialSign(curve, msg, privkeys[i].Serialize(),
+			aggPubBytes[:], coeffs[i], nonces[i].Serialize(), pubNonceSumBytes[:])
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		sigs[i] = NewSignature(r, s)
+	}
+
+	combined, err := MuSig2CombineSigs(curve, sigs)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	if !MuSig2Verify(aggPub, msg, combined) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"crypto/sha512"
+	"errors"
+	"math/big"
+)
+
+// detNonceDomain is prepended to every DeterministicNonce digest so that
+// its output space is disjoint from nonceRFC6979's and from any other
+// hash used elsewhere in this package.
+const detNonceDomain = "HCD/ed25519-nonce"
+
+// DeterministicNonce derives a 32-byte clamped nonce scalar for a single
+// signing session, binding in the aggregate public key, the session ID and
+// optional auxiliary randomness alongside the usual private key and
+// message. Where nonceRFC6979 depends only on the private key and message,
+// DeterministicNonce additionally defeats nonce reuse across concurrent
+// MuSig2 sessions that share the same signer and message but a different
+// aggregate key or co-signer set, and k-list attacks that rely on a
+// coordinator observing and correlating nonces across sessions.
+//
+// The nonce is SHA-512("HCD/ed25519-nonce" || SHA-512("HCD/ed25519-nonce")
+// || priv XOR TaggedHash("HCD/ed25519-aux", aux) || aggPub || sessionID ||
+// msg), reduced mod curve.N (which leaves the high bits clear) and then
+// clamped by clearing its low three bits (nonce[31] &= 248); bit 254 is
+// not set. SchnorrPartialSignDeterministic and GenNonces derive their
+// nonces with it; schnorrPartialSign itself still takes a caller-supplied
+// nonce, and nonceRFC6979 remains reachable directly for legacy
+// single-signer signatures that predate this scheme.
+func DeterministicNonce(curve *TwistedEdwardsCurve, privBytes, aggPubBytes, msg, aux, sessionID []byte) ([]byte, error) {
+	maskedPriv := make([]byte, len(privBytes))
+	if aux != nil {
+		auxHash := TaggedHash("HCD/ed25519-aux", aux)
+		for i := range maskedPriv {
+			maskedPriv[i] = privBytes[i] ^ auxHash[i%len(auxHash)]
+		}
+	} else {
+		copy(maskedPriv, privBytes)
+	}
+
+	domainHash := sha512.Sum512([]byte(detNonceDomain))
+
+	h := sha512.New()
+	h.Write([]byte(detNonceDomain))
+	h.Write(domainHash[:])
+	h.Write(maskedPriv)
+	h.Write(aggPubBytes)
+	h.Write(sessionID)
+	h.Write(msg)
+
+	nonce := new(big.Int).SetBytes(h.Sum(nil))
+	nonce.Mod(nonce, curve.N)
+	if nonce.Sign() == 0 {
+		return nil, errors.New("edwards: deterministic nonce reduced to zero, aborting rather than truncating")
+	}
+
+	nonceBytes := copyBytes(nonce.Bytes())
+	nonceBytes[31] &= 248
+
+	return nonceBytes[:], nil
+}
+
+// SchnorrPartialSignDeterministic is schnorrPartialSign with its nonce
+// derived by DeterministicNonce instead of supplied by the caller, so that
+// the single-nonce threshold path gets the same session/aggregate-key
+// binding that GenNonces already gives the two-nonce MuSig2 path, rather
+// than requiring every caller to generate and thread a nonce by hand.
+// sessionID must be unique per signing session; aux may be nil.
+func SchnorrPartialSignDeterministic(curve *TwistedEdwardsCurve, msg, priv, aggPub []byte,
+	pubNonceSum *PublicKey, aux, sessionID []byte) (*big.Int, *big.Int, error) {
+
+	nonceBytes, err := DeterministicNonce(curve, priv, aggPub, msg, aux, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return schnorrPartialSign(curve, msg, priv, aggPub, nonceBytes, pubNonceSum.Serialize())
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestDeterministicNonce checks that DeterministicNonce is a deterministic
+// function of all of its inputs: changing the aggregate key, session ID or
+// auxiliary randomness must each change the resulting nonce, and a clamped
+// 32-byte scalar must always come back for well-formed inputs.
+func TestDeterministicNonce(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	priv := randPrivScalarKeyList(curve, 1)[0].Serialize()
+	aggPub := randPrivScalarKeyList(curve, 1)[0].Serialize()
+	msg, _ := hex.DecodeString(
+		"d04b98f48e8f8bcc15c6ae5ac050801cd6dcfd428fb5f9e65c4e16e7807340fa")
+	sessionID := []byte("session-1")
+
+	nonce1, err := DeterministicNonce(curve, priv[:], aggPub[:], msg, nil, sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	nonce2, err := DeterministicNonce(curve, priv[:], aggPub[:], msg, nil, sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if !bytes.Equal(nonce1, nonce2) {
+		t.Fatalf("expected deterministic nonce generation to be repeatable")
+	}
+	if len(nonce1) != 32 {
+		t.Fatalf("expected %v, got %v", 32, len(nonce1))
+	}
+	if nonce1[31]&0x07 != 0 {
+		t.Fatalf("expected low clamp bits to be cleared, got %08b", nonce1[31])
+	}
+
+	otherAggPubBytes := append([]byte{}, aggPub[:]...)
+	otherAggPubBytes[0] ^= 1
+	otherAggPub, err := DeterministicNonce(curve, priv[:], otherAggPubBytes, msg, nil, sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if bytes.Equal(nonce1, otherAggPub) {
+		t.Fatalf("expected different aggregate keys to produce different nonces")
+	}
+
+	otherSession, err := DeterministicNonce(curve, priv[:], aggPub[:], msg, nil, []byte("session-2"))
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if bytes.Equal(nonce1, otherSession) {
+		t.Fatalf("expected different session IDs to produce different nonces")
+	}
+
+	otherAux, err := DeterministicNonce(curve, priv[:], aggPub[:], msg, []byte("aux"), sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if bytes.Equal(nonce1, otherAux) {
+		t.Fatalf("expected auxiliary randomness to change the nonce")
+	}
+}
+
+// TestSchnorrPartialSignDeterministic checks that the single-nonce
+// threshold path produces a verifying signature when its nonce comes from
+// DeterministicNonce instead of a caller-supplied one.
+func TestSchnorrPartialSignDeterministic(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	msg, _ := hex.DecodeString(
+		"d04b98f48e8f8bcc15c6ae5ac050801cd6dcfd428fb5f9e65c4e16e7807340fa")
+
+	privKey := randPrivScalarKeyList(curve, 1)[0]
+	priv, pub, err := PrivKeyFromScalar(curve, privKey.Serialize())
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	aggPub := CombinePubkeys(curve, []*PublicKey{pub})
+
+	nonceBytes, err := DeterministicNonce(curve, priv.Serialize(), aggPub.Serialize(),
+		msg, nil, []byte("session-1"))
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	_, pubNonce, err := PrivKeyFromScalar(curve, nonceBytes)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	pubNonceSum := CombinePubkeys(curve, []*PublicKey{pubNonce})
+
+	r, s, err := SchnorrPartialSignDeterministic(curve, msg, priv.Serialize(),
+		aggPub.Serialize(), pubNonceSum, nil, []byte("session-1"))
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	sig := NewSignature(r, s)
+
+	if !Verify(aggPub, msg, sig.GetR(), sig.GetS()) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
@@ -8,24 +8,56 @@ package edwards
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"testing"
 )
 
+// signerHex is one signer's JSON-encoded material in a frozen test
+// vector file under testdata/. Which fields are populated depends on the
+// vector's Protocol: plain threshold vectors use every field except
+// Pubkey and KeyAggCoeff (PubKeySumLocal already carries the aggregate);
+// musig2-two-nonce
+// vectors use Privkey, Pubkey and KeyAggCoeff (the nonce and aggregate-key
+// fields are vector-level, not per signer, since MuSig2's two-nonce
+// scheme aggregates nonces across the whole signer set before any one
+// signer's partial signature can be computed) -- Pubkey is recorded
+// separately from Privkey because a corrupt vector's Privkey no longer
+// matches the honest pubkey the vector was actually aggregated and
+// signed under; adaptor vectors reuse PrivateNonce for the single
+// signer's AdaptorSign nonce scalar and otherwise use Privkey only.
 type signerHex struct {
-	privkey          string
-	privateNonce     string
-	pubKeySumLocal   string
-	partialSignature string
+	Privkey          string `json:"privkey"`
+	Pubkey           string `json:"pubkey,omitempty"`
+	PrivateNonce     string `json:"privateNonce,omitempty"`
+	PubKeySumLocal   string `json:"pubKeySumLocal,omitempty"`
+	PartialSignature string `json:"partialSignature,omitempty"`
+	KeyAggCoeff      string `json:"keyAggCoeff,omitempty"`
 }
 
+// ThresholdTestVectorHex is the on-disk shape of one vector in
+// testdata/threshold_vectors.json, as produced by
+// cmd/edwards-gen-vectors. One schema covers all three protocols this
+// package implements; see signerHex and decode for which fields apply to
+// which Protocol.
 type ThresholdTestVectorHex struct {
-	msg               string
-	signersHex        []signerHex
-	combinedSignature string
+	Description       string      `json:"description"`
+	Protocol          string      `json:"protocol"`
+	Msg               string      `json:"msg"`
+	SignersHex        []signerHex `json:"signers,omitempty"`
+	NonceR1           string      `json:"nonceR1,omitempty"`
+	NonceR2           string      `json:"nonceR2,omitempty"`
+	CombinedSignature string      `json:"combinedSignature,omitempty"`
+	T                 string      `json:"T,omitempty"`
+	TSecret           string      `json:"t,omitempty"`
+	AdaptorR          string      `json:"adaptorR,omitempty"`
+	AdaptorS          string      `json:"adaptorS,omitempty"`
+	Corrupt           bool        `json:"corrupt"`
 }
 
+// signer is one signer's decoded material, ready to be replayed through
+// the real signing pipeline. Which fields are populated mirrors signerHex.
 type signer struct {
 	privkey          []byte
 	pubkey           *PublicKey
@@ -33,12 +65,146 @@ type signer struct {
 	publicNonce      *PublicKey
 	pubKeySumLocal   *PublicKey
 	partialSignature []byte
+	keyAggCoeff      *big.Int
 }
 
+// ThresholdTestVector is a ThresholdTestVectorHex with every hex field
+// decoded to the bytes/points the production functions expect.
 type ThresholdTestVector struct {
+	protocol          string
 	msg               []byte
 	signers           []signer
+	nonceR1           *PublicKey
+	nonceR2           *PublicKey
 	combinedSignature []byte
+	t                 *PublicKey
+	tSecret           []byte
+	adaptorR          *PublicKey
+	adaptorS          *big.Int
+	corrupt           bool
+}
+
+// decode converts a ThresholdTestVectorHex loaded from JSON into a
+// ThresholdTestVector, parsing every hex string into the bytes or curve
+// point the real schnorrPartialSign/MuSig2Sign/AdaptorSign pipeline
+// expects. It does not validate the vector; TestVectorsFromFile does that
+// by actually driving the pipeline with the decoded values.
+func (vh *ThresholdTestVectorHex) decode(curve *TwistedEdwardsCurve) (*ThresholdTestVector, error) {
+	msg, err := hex.DecodeString(vh.Msg)
+	if err != nil {
+		return nil, fmt.Errorf("msg: %w", err)
+	}
+
+	v := &ThresholdTestVector{protocol: vh.Protocol, msg: msg, corrupt: vh.Corrupt}
+
+	v.signers = make([]signer, len(vh.SignersHex))
+	for i, sh := range vh.SignersHex {
+		var s signer
+		if s.privkey, err = hex.DecodeString(sh.Privkey); err != nil {
+			return nil, fmt.Errorf("signers[%d].privkey: %w", i, err)
+		}
+		if sh.Pubkey != "" {
+			pubkeyBytes, err := hex.DecodeString(sh.Pubkey)
+			if err != nil {
+				return nil, fmt.Errorf("signers[%d].pubkey: %w", i, err)
+			}
+			if s.pubkey, err = ParsePubKey(curve, pubkeyBytes); err != nil {
+				return nil, fmt.Errorf("signers[%d].pubkey: %w", i, err)
+			}
+		} else {
+			_, s.pubkey, err = PrivKeyFromScalar(curve, s.privkey)
+			if err != nil {
+				return nil, fmt.Errorf("signers[%d].privkey: %w", i, err)
+			}
+		}
+		if sh.PrivateNonce != "" {
+			if s.privateNonce, err = hex.DecodeString(sh.PrivateNonce); err != nil {
+				return nil, fmt.Errorf("signers[%d].privateNonce: %w", i, err)
+			}
+			_, s.publicNonce, err = PrivKeyFromScalar(curve, s.privateNonce)
+			if err != nil {
+				return nil, fmt.Errorf("signers[%d].privateNonce: %w", i, err)
+			}
+		}
+		if sh.PubKeySumLocal != "" {
+			pubKeySumBytes, err := hex.DecodeString(sh.PubKeySumLocal)
+			if err != nil {
+				return nil, fmt.Errorf("signers[%d].pubKeySumLocal: %w", i, err)
+			}
+			if s.pubKeySumLocal, err = ParsePubKey(curve, pubKeySumBytes); err != nil {
+				return nil, fmt.Errorf("signers[%d].pubKeySumLocal: %w", i, err)
+			}
+		}
+		if sh.PartialSignature != "" {
+			if s.partialSignature, err = hex.DecodeString(sh.PartialSignature); err != nil {
+				return nil, fmt.Errorf("signers[%d].partialSignature: %w", i, err)
+			}
+		}
+		if sh.KeyAggCoeff != "" {
+			coeffBytes, err := hex.DecodeString(sh.KeyAggCoeff)
+			if err != nil {
+				return nil, fmt.Errorf("signers[%d].keyAggCoeff: %w", i, err)
+			}
+			s.keyAggCoeff = new(big.Int).SetBytes(coeffBytes)
+		}
+		v.signers[i] = s
+	}
+
+	if vh.NonceR1 != "" {
+		r1Bytes, err := hex.DecodeString(vh.NonceR1)
+		if err != nil {
+			return nil, fmt.Errorf("nonceR1: %w", err)
+		}
+		if v.nonceR1, err = ParsePubKey(curve, r1Bytes); err != nil {
+			return nil, fmt.Errorf("nonceR1: %w", err)
+		}
+	}
+	if vh.NonceR2 != "" {
+		r2Bytes, err := hex.DecodeString(vh.NonceR2)
+		if err != nil {
+			return nil, fmt.Errorf("nonceR2: %w", err)
+		}
+		if v.nonceR2, err = ParsePubKey(curve, r2Bytes); err != nil {
+			return nil, fmt.Errorf("nonceR2: %w", err)
+		}
+	}
+	if vh.CombinedSignature != "" {
+		if v.combinedSignature, err = hex.DecodeString(vh.CombinedSignature); err != nil {
+			return nil, fmt.Errorf("combinedSignature: %w", err)
+		}
+	}
+	if vh.T != "" {
+		tBytes, err := hex.DecodeString(vh.T)
+		if err != nil {
+			return nil, fmt.Errorf("T: %w", err)
+		}
+		if v.t, err = ParsePubKey(curve, tBytes); err != nil {
+			return nil, fmt.Errorf("T: %w", err)
+		}
+	}
+	if vh.TSecret != "" {
+		if v.tSecret, err = hex.DecodeString(vh.TSecret); err != nil {
+			return nil, fmt.Errorf("t: %w", err)
+		}
+	}
+	if vh.AdaptorR != "" {
+		rBytes, err := hex.DecodeString(vh.AdaptorR)
+		if err != nil {
+			return nil, fmt.Errorf("adaptorR: %w", err)
+		}
+		if v.adaptorR, err = ParsePubKey(curve, rBytes); err != nil {
+			return nil, fmt.Errorf("adaptorR: %w", err)
+		}
+	}
+	if vh.AdaptorS != "" {
+		sBytes, err := hex.DecodeString(vh.AdaptorS)
+		if err != nil {
+			return nil, fmt.Errorf("adaptorS: %w", err)
+		}
+		v.adaptorS = new(big.Int).SetBytes(sBytes)
+	}
+
+	return v, nil
 }
 
 func TestSchnorrThreshold(t *testing.T) {
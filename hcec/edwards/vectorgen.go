@@ -0,0 +1,19 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import "math/big"
+
+// SchnorrPartialSignRaw exposes the package's internal partial-signing step
+// to tooling outside this package, namely cmd/edwards-gen-vectors, which
+// needs to drive the real signing pipeline to produce test vectors rather
+// than hand-rolling a parallel implementation that could drift from it. It
+// is named ...Raw, rather than reusing SchnorrPartialSign, because that
+// name and a different argument order are already taken by the
+// higher-level, typed entry point in threshold.go.
+func SchnorrPartialSignRaw(curve *TwistedEdwardsCurve, msg, priv, aggPub, privNonce, pubNonceSum []byte) (*big.Int, *big.Int, error) {
+	return schnorrPartialSign(curve, msg, priv, aggPub, privNonce, pubNonceSum)
+}
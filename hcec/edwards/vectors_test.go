@@ -0,0 +1,500 @@
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// vectorsPath is where TestVectorsFromFile expects its frozen fixture,
+// matching cmd/edwards-gen-vectors' own suggested redirect target.
+const vectorsPath = "testdata/threshold_vectors.json"
+
+// vectorsFile is the top-level shape cmd/edwards-gen-vectors writes.
+type vectorsFile struct {
+	Vectors []ThresholdTestVectorHex `json:"vectors"`
+}
+
+// TestVectorsFromFile is this package's file-driven test-vector harness:
+// it loads the frozen vectors in testdata/threshold_vectors.json and
+// replays every one through the real threshold, MuSig2 two-nonce and
+// adaptor signing pipelines, checking every recorded value byte-for-byte
+// rather than only ever generating and checking vectors live. This test
+// does not generate vectors itself -- that is cmd/edwards-gen-vectors'
+// job, so there is exactly one implementation of vector generation to
+// keep in sync with the signing pipeline. Refresh the fixture from the
+// repository root with:
+//
+//	go run ./hcec/cmd/edwards-gen-vectors > hcec/edwards/testdata/threshold_vectors.json
+//
+// If the fixture is missing, this test fails outright rather than
+// generating one on the fly: a test that silently writes fresh,
+// non-deterministic vectors into the source tree on every run isn't
+// frozen, has no regression value, and mutates the working tree as a
+// side effect of `go test`.
+func TestVectorsFromFile(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	vectors, err := loadVectors(vectorsPath)
+	if err != nil {
+		t.Fatalf("loading %s: %s -- run `go run ../cmd/edwards-gen-vectors > %s` in hcec/edwards to generate it",
+			vectorsPath, err, vectorsPath)
+	}
+	if len(vectors) < 30 {
+		t.Fatalf("expected at least %v vectors, got %v", 30, len(vectors))
+	}
+
+	for i, vh := range vectors {
+		vh := vh
+		name := vh.Description
+		if name == "" {
+			name = fmt.Sprintf("vector-%d", i)
+		}
+		t.Run(name, func(t *testing.T) {
+			v, err := vh.decode(curve)
+			if err != nil {
+				t.Fatalf("decoding vector: %s", err)
+			}
+			switch v.protocol {
+			case "threshold":
+				runThresholdVectorFromFile(t, curve, v)
+			case "musig2-two-nonce":
+				runMuSig2VectorFromFile(t, curve, v)
+			case "adaptor":
+				runAdaptorVectorFromFile(t, curve, v)
+			default:
+				t.Fatalf("unknown protocol %q", v.protocol)
+			}
+		})
+	}
+}
+
+// runThresholdVectorFromFile replays a plain threshold vector: every
+// signer's partial signature is recomputed from its recorded privkey,
+// privateNonce and pubKeySumLocal and checked byte-for-byte against the
+// recorded partialSignature, the partials are combined and checked
+// against the recorded combinedSignature, and the combined signature's
+// verification result is checked against the vector's corrupt flag.
+func runThresholdVectorFromFile(t *testing.T, curve *TwistedEdwardsCurve, v *ThresholdTestVector) {
+	t.Helper()
+
+	if len(v.signers) == 0 {
+		t.Fatalf("threshold vector has no signers")
+	}
+	aggPub := v.signers[0].pubKeySumLocal
+
+	// schnorrPartialSign's returned r is the serialized aggregate public
+	// nonce; every signer's recorded partialSignature therefore carries
+	// the same R, which is how pubNonceSum is recovered without a
+	// dedicated JSON field for it.
+	pubNonceSum, err := ParsePubKey(curve, v.signers[0].partialSignature[:32])
+	if err != nil {
+		t.Fatalf("recovering pubNonceSum from partialSignature: %s", err)
+	}
+	pubNonceSumBytes := pubNonceSum.Serialize()
+
+	sigs := make([]*Signature, len(v.signers))
+	for i, s := range v.signers {
+		r, sVal, err := schnorrPartialSign(curve, v.msg, s.privkey, aggPub.Serialize(),
+			s.privateNonce, pubNonceSumBytes[:])
+		if err != nil {
+			t.Fatalf("signers[%d]: unexpected error %s, ", i, err)
+		}
+		sig := NewSignature(r, sVal)
+		if !bytesEqual(sig.Serialize(), s.partialSignature) {
+			t.Fatalf("signers[%d]: recomputed partial signature does not match recorded vector", i)
+		}
+		sigs[i] = sig
+	}
+
+	combined, err := SchnorrCombineSigs(curve, sigs)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if !bytesEqual(combined.Serialize(), v.combinedSignature) {
+		t.Fatalf("recomputed combined signature does not match recorded vector")
+	}
+
+	ok := Verify(aggPub, v.msg, combined.GetR(), combined.GetS())
+	if ok == v.corrupt {
+		t.Fatalf("expected verification success %v, got %v", !v.corrupt, ok)
+	}
+}
+
+// runMuSig2VectorFromFile replays a two-nonce MuSig2 vector: each signer's
+// key-aggregation coefficient and nonce pair are rederived exactly as
+// cmd/edwards-gen-vectors produced them (deterministically, from the
+// recorded privkey and the sessionID convention below), checked against
+// the recorded aggregate nonce pair, and the resulting combined signature
+// is checked against the recorded one and the corrupt flag.
+func runMuSig2VectorFromFile(t *testing.T, curve *TwistedEdwardsCurve, v *ThresholdTestVector) {
+	t.Helper()
+
+	pubs := make([]*PublicKey, len(v.signers))
+	for i, s := range v.signers {
+		pubs[i] = s.pubkey
+	}
+	aggPub, coeffs, err := AggregatePubKeys(curve, pubs)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	aggPubBytes := aggPub.Serialize()
+
+	for i, s := range v.signers {
+		if coeffs[i].Cmp(s.keyAggCoeff) != 0 {
+			t.Fatalf("signers[%d]: recomputed keyAggCoeff does not match recorded vector", i)
+		}
+	}
+
+	secNonces := make([]*SecNonces, len(v.signers))
+	pubNonces := make([]*PubNonces, len(v.signers))
+	for i, s := range v.signers {
+		sessionID := []byte{byte(i)}
+		sec, pub, err := GenNonces(curve, s.privkey, aggPubBytes[:], v.msg, nil, sessionID)
+		if err != nil {
+			t.Fatalf("signers[%d]: unexpected error %s, ", i, err)
+		}
+		secNonces[i] = sec
+		pubNonces[i] = pub
+	}
+
+	aggNonces, err := AggregateNonces(curve, pubNonces)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	r1 := aggNonces.GetR1().Serialize()
+	r2 := aggNonces.GetR2().Serialize()
+	if !bytesEqual(r1[:], v.nonceR1.Serialize()[:]) {
+		t.Fatalf("recomputed nonceR1 does not match recorded vector")
+	}
+	if !bytesEqual(r2[:], v.nonceR2.Serialize()[:]) {
+		t.Fatalf("recomputed nonceR2 does not match recorded vector")
+	}
+
+	sigs := make([]*Signature, len(v.signers))
+	for i, s := range v.signers {
+		r, sVal, err := MuSig2Sign(curve, secNonces[i], aggNonces, aggPub, coeffs[i], s.privkey, v.msg)
+		if err != nil {
+			t.Fatalf("signers[%d]: unexpected error %s, ", i, err)
+		}
+		sigs[i] = NewSignature(r, sVal)
+	}
+
+	combined, err := MuSig2CombineSigs(curve, sigs)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if !bytesEqual(combined.Serialize(), v.combinedSignature) {
+		t.Fatalf("recomputed combined signature does not match recorded vector")
+	}
+
+	ok := MuSig2Verify(aggPub, v.msg, combined)
+	if ok == v.corrupt {
+		t.Fatalf("expected verification success %v, got %v", !v.corrupt, ok)
+	}
+}
+
+// runAdaptorVectorFromFile replays an adaptor-signature vector using its
+// recorded nonce rather than a freshly generated one, so the adaptor
+// signature and the completed signature it adapts to are both checked
+// against fixed, recorded bytes -- not just re-derived and self-checked
+// against fresh randomness. It then adapts with the recorded tweak secret
+// and extracts, checking the extracted secret against the recorded one
+// for honest vectors and checking that adapting with a recorded-wrong
+// secret fails to verify for corrupt ones.
+func runAdaptorVectorFromFile(t *testing.T, curve *TwistedEdwardsCurve, v *ThresholdTestVector) {
+	t.Helper()
+
+	if len(v.signers) != 1 {
+		t.Fatalf("adaptor vector must have exactly one signer, got %d", len(v.signers))
+	}
+	priv := v.signers[0]
+	if priv.privateNonce == nil {
+		t.Fatalf("adaptor vector has no recorded nonce")
+	}
+
+	adaptor, err := AdaptorSign(curve, priv.privkey, priv.privateNonce, v.msg, v.t)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if !bytesEqual(adaptor.GetR().Serialize(), v.adaptorR.Serialize()) {
+		t.Fatalf("recomputed adaptor R does not match recorded vector")
+	}
+	if adaptor.GetS().Cmp(v.adaptorS) != 0 {
+		t.Fatalf("recomputed adaptor S does not match recorded vector")
+	}
+	if err := AdaptorVerify(curve, priv.pubkey, v.msg, v.t, adaptor); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	completed := AdaptorAdapt(curve, adaptor, v.tSecret, v.t)
+	if !bytesEqual(completed.Serialize(), v.combinedSignature) {
+		t.Fatalf("recomputed completed signature does not match recorded vector")
+	}
+
+	ok := Verify(priv.pubkey, v.msg, completed.GetR(), completed.GetS())
+	if v.corrupt {
+		if ok {
+			t.Fatalf("expected corrupted tweak secret to fail verification")
+		}
+		return
+	}
+	if !ok {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	extracted, err := AdaptorExtract(curve, adaptor, completed)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if hex.EncodeToString(extracted) != hex.EncodeToString(v.tSecret) {
+		t.Fatalf("expected %x, got %x", v.tSecret, extracted)
+	}
+}
+
+func bytesEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// loadVectors loads path's frozen vector set. It does not generate one if
+// absent -- cmd/edwards-gen-vectors is the single source of truth for
+// vector generation, and a loader that fabricated its own vectors on a
+// cache miss would defeat the point of freezing them at all.
+func loadVectors(path string) ([]ThresholdTestVectorHex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file vectorsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return file.Vectors, nil
+}
+
+// TestThresholdVectors exercises real positive and corrupted-negative cases
+// for 2-of-2 through 10-of-10 against schnorrPartialSign, SchnorrCombineSigs
+// and Verify, generating vectors live rather than loading them from disk.
+// TestVectorsFromFile above covers the same ground plus MuSig2 and adaptor
+// signatures against a frozen, file-driven fixture; this test is kept
+// alongside it as a fast, self-contained sanity check that does not
+// depend on testdata/ existing.
+func TestThresholdVectors(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	for n := 2; n <= 10; n++ {
+		n := n
+		t.Run(fmt.Sprintf("live-%d-of-%d", n, n), func(t *testing.T) {
+			runLiveThresholdVector(t, curve, n, false)
+		})
+		t.Run(fmt.Sprintf("live-%d-of-%d-corrupted", n, n), func(t *testing.T) {
+			runLiveThresholdVector(t, curve, n, true)
+		})
+	}
+}
+
+// runLiveThresholdVector runs n signers through the real threshold signing
+// pipeline. When corrupt is true, one signer's private key is flipped
+// after key aggregation so that their partial signature no longer matches
+// the aggregate pubkey, and the combined signature is expected to fail
+// verification rather than to error out -- corrupting a valid scalar to
+// another valid scalar does not make schnorrPartialSign itself fail.
+func runLiveThresholdVector(t *testing.T, curve *TwistedEdwardsCurve, n int, corrupt bool) {
+	t.Helper()
+
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	privs := randPrivScalarKeyList(curve, n)
+	pubs := make([]*PublicKey, n)
+	for i := range privs {
+		_, pub, err := PrivKeyFromScalar(curve, privs[i].Serialize())
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		pubs[i] = pub
+	}
+	aggPub := CombinePubkeys(curve, pubs)
+
+	nonces := randPrivScalarKeyList(curve, n)
+	pubNonces := make([]*PublicKey, n)
+	for i := range nonces {
+		_, pubNonce, err := PrivKeyFromScalar(curve, nonces[i].Serialize())
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		pubNonces[i] = pubNonce
+	}
+	pubNonceSum := CombinePubkeys(curve, pubNonces)
+
+	signerPrivBytes := make([][]byte, n)
+	for i := range privs {
+		signerPrivBytes[i] = privs[i].Serialize()
+	}
+	if corrupt {
+		corrupted := make([]byte, len(signerPrivBytes[0]))
+		copy(corrupted, signerPrivBytes[0])
+		corrupted[0] ^= 1
+		signerPrivBytes[0] = corrupted
+	}
+
+	sigs := make([]*Signature, n)
+	for i := range privs {
+		r, s, err := schnorrPartialSign(curve, msg, signerPrivBytes[i],
+			aggPub.Serialize(), nonces[i].Serialize(), pubNonceSum.Serialize())
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		sigs[i] = NewSignature(r, s)
+	}
+
+	combined, err := SchnorrCombineSigs(curve, sigs)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	verified := Verify(aggPub, msg, combined.GetR(), combined.GetS())
+	if corrupt {
+		if verified {
+			t.Fatalf("expected corrupted combined signature to fail verification")
+		}
+		return
+	}
+	if !verified {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
+
+// TestMuSig2AndAdaptorVectorsRoundTrip drives real sign/combine/verify
+// (single-nonce MuSig2PartialSign) and sign/verify/adapt/extract (adaptor)
+// round trips live. TestVectorsFromFile's file-driven MuSig2 coverage uses
+// the two-nonce MuSig2Sign path instead.
+func TestMuSig2AndAdaptorVectorsRoundTrip(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	runLiveMuSig2Vector(t, curve)
+	runLiveAdaptorVector(t, curve)
+}
+
+// runLiveMuSig2Vector drives a full MuSig2 key-aggregation, partial-sign,
+// combine and verify round trip.
+func runLiveMuSig2Vector(t *testing.T, curve *TwistedEdwardsCurve) {
+	t.Helper()
+
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	numSignatories := 3
+	privkeys := randPrivScalarKeyList(curve, numSignatories)
+	nonces := randPrivScalarKeyList(curve, numSignatories)
+
+	pubkeys := make([]*PublicKey, numSignatories)
+	pubNonces := make([]*PublicKey, numSignatories)
+	for i := range privkeys {
+		_, pub, err := PrivKeyFromScalar(curve, privkeys[i].Serialize())
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		pubkeys[i] = pub
+
+		_, pubNonce, err := PrivKeyFromScalar(curve, nonces[i].Serialize())
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		pubNonces[i] = pubNonce
+	}
+
+	aggPub, coeffs, err := AggregatePubKeys(curve, pubkeys)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	aggPubBytes := aggPub.Serialize()
+	pubNonceSum := CombinePubkeys(curve, pubNonces)
+	pubNonceSumBytes := pubNonceSum.Serialize()
+
+	sigs := make([]*Signature, numSignatories)
+	for i := range privkeys {
+		r, s, err := MuSig2PartialSign(curve, msg, privkeys[i].Serialize(),
+			aggPubBytes, coeffs[i], nonces[i].Serialize(), pubNonceSumBytes)
+		if err != nil {
+			t.Fatalf("unexpected error %s, ", err)
+		}
+		sigs[i] = NewSignature(r, s)
+	}
+
+	combined, err := MuSig2CombineSigs(curve, sigs)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if !MuSig2Verify(aggPub, msg, combined) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+}
+
+// runLiveAdaptorVector drives a full adaptor-signature sign, verify, adapt
+// and extract round trip.
+func runLiveAdaptorVector(t *testing.T, curve *TwistedEdwardsCurve) {
+	t.Helper()
+
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	priv := randPrivScalarKeyList(curve, 1)[0]
+	_, pub, err := PrivKeyFromScalar(curve, priv.Serialize())
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	nonce := randPrivScalarKeyList(curve, 1)[0]
+
+	tSecret := randPrivScalarKeyList(curve, 1)[0].Serialize()
+	_, tPoint, err := PrivKeyFromScalar(curve, tSecret)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	adaptor, err := AdaptorSign(curve, priv.Serialize(), nonce.Serialize(), msg, tPoint)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if err := AdaptorVerify(curve, pub, msg, tPoint, adaptor); err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+
+	completed := AdaptorAdapt(curve, adaptor, tSecret, tPoint)
+	if !Verify(pub, msg, completed.GetR(), completed.GetS()) {
+		t.Fatalf("expected %v, got %v", true, false)
+	}
+
+	extracted, err := AdaptorExtract(curve, adaptor, completed)
+	if err != nil {
+		t.Fatalf("unexpected error %s, ", err)
+	}
+	if hex.EncodeToString(extracted) != hex.EncodeToString(tSecret) {
+		t.Fatalf("expected %x, got %x", tSecret, extracted)
+	}
+}